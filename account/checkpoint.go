@@ -0,0 +1,38 @@
+package account
+
+// RecoveryProgress captures how far a recovery run has gotten, so that it
+// can be resumed without repeating already completed work.
+type RecoveryProgress struct {
+	// LastTraderKeyIndex is the index, within GenerateRecoveryKeys, of the
+	// last trader key that was tried.
+	LastTraderKeyIndex uint32
+
+	// LastBatchKeyIndex is the batch key index at which the search of
+	// the trader key above resumes; every index below it has already
+	// been searched. Index i stands for the batch key i+1 increments past
+	// cfg.InitialBatchKey, and defaultMaxNumBatchKeys means the search
+	// of that trader key is finished.
+	LastBatchKeyIndex uint32
+
+	// LastScannedBlock is the last height of the expiry search range
+	// (cfg.LastBlock), recorded once account states have been updated.
+	// It is zero while the initial search is still running, and it is
+	// not read when a run is resumed.
+	LastScannedBlock uint32
+
+	// RecoveredAccounts holds every account recovered so far, along with
+	// their most up to date on chain state.
+	RecoveredAccounts []*Account
+}
+
+// RecoveryCheckpoint persists and reloads the progress of a recovery run, so
+// that a run interrupted partway through a wide search (many candidate keys
+// over a wide block range) doesn't have to restart from scratch.
+type RecoveryCheckpoint interface {
+	// Save persists progress, overwriting whatever was previously saved.
+	Save(progress *RecoveryProgress) error
+
+	// Load returns the most recently saved progress, or nil if no
+	// checkpoint has been saved yet.
+	Load() (*RecoveryProgress, error)
+}
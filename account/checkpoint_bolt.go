@@ -0,0 +1,250 @@
+package account
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightningnetwork/lnd/keychain"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	// progressBucket holds the single progressKey entry describing how
+	// far the current recovery run has gotten.
+	progressBucket = []byte("recovery-progress")
+	progressKey    = []byte("progress")
+)
+
+// BoltCheckpoint is a bbolt-backed implementation of RecoveryCheckpoint.
+type BoltCheckpoint struct {
+	db *bbolt.DB
+}
+
+// NewBoltCheckpoint opens (creating if necessary) a bbolt database at dbPath
+// to use for checkpointing recovery progress.
+func NewBoltCheckpoint(dbPath string) (*BoltCheckpoint, error) {
+	db, err := bbolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open checkpoint db: %v", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(progressBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create progress bucket: "+
+			"%v", err)
+	}
+
+	return &BoltCheckpoint{db: db}, nil
+}
+
+// Close closes the underlying bbolt database.
+func (b *BoltCheckpoint) Close() error {
+	return b.db.Close()
+}
+
+// Save persists progress, overwriting whatever was previously saved.
+//
+// NOTE: This is part of the RecoveryCheckpoint interface.
+func (b *BoltCheckpoint) Save(progress *RecoveryProgress) error {
+	var buf bytes.Buffer
+	if err := serializeProgress(&buf, progress); err != nil {
+		return fmt.Errorf("unable to serialize progress: %v", err)
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(progressBucket)
+		return bucket.Put(progressKey, buf.Bytes())
+	})
+}
+
+// Load returns the most recently saved progress, or nil if no checkpoint has
+// been saved yet.
+//
+// NOTE: This is part of the RecoveryCheckpoint interface.
+func (b *BoltCheckpoint) Load() (*RecoveryProgress, error) {
+	var progress *RecoveryProgress
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(progressBucket)
+		raw := bucket.Get(progressKey)
+		if raw == nil {
+			return nil
+		}
+
+		p, err := deserializeProgress(bytes.NewReader(raw))
+		if err != nil {
+			return err
+		}
+
+		progress = p
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load progress: %v", err)
+	}
+
+	return progress, nil
+}
+
+func serializeProgress(w *bytes.Buffer, progress *RecoveryProgress) error {
+	if err := binary.Write(w, binary.BigEndian, progress.LastTraderKeyIndex); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, progress.LastBatchKeyIndex); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, progress.LastScannedBlock); err != nil {
+		return err
+	}
+
+	numAccounts := uint32(len(progress.RecoveredAccounts))
+	if err := binary.Write(w, binary.BigEndian, numAccounts); err != nil {
+		return err
+	}
+
+	for _, acc := range progress.RecoveredAccounts {
+		if err := serializeAccount(w, acc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deserializeProgress(r *bytes.Reader) (*RecoveryProgress, error) {
+	progress := &RecoveryProgress{}
+
+	if err := binary.Read(r, binary.BigEndian, &progress.LastTraderKeyIndex); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &progress.LastBatchKeyIndex); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &progress.LastScannedBlock); err != nil {
+		return nil, err
+	}
+
+	var numAccounts uint32
+	if err := binary.Read(r, binary.BigEndian, &numAccounts); err != nil {
+		return nil, err
+	}
+
+	progress.RecoveredAccounts = make([]*Account, numAccounts)
+	for i := uint32(0); i < numAccounts; i++ {
+		acc, err := deserializeAccount(r)
+		if err != nil {
+			return nil, err
+		}
+
+		progress.RecoveredAccounts[i] = acc
+	}
+
+	return progress, nil
+}
+
+func serializeAccount(w *bytes.Buffer, acc *Account) error {
+	if err := binary.Write(w, binary.BigEndian, acc.TraderKey.Family); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, acc.TraderKey.Index); err != nil {
+		return err
+	}
+	w.Write(acc.TraderKey.PubKey.SerializeCompressed())
+	w.Write(acc.AuctioneerKey.SerializeCompressed())
+	w.Write(acc.BatchKey.SerializeCompressed())
+	w.Write(acc.Secret[:])
+
+	if err := binary.Write(w, binary.BigEndian, acc.Expiry); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint8(acc.Version)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, int64(acc.Value)); err != nil {
+		return err
+	}
+
+	w.Write(acc.OutPoint.Hash[:])
+	if err := binary.Write(w, binary.BigEndian, acc.OutPoint.Index); err != nil {
+		return err
+	}
+
+	return acc.LatestTx.Serialize(w)
+}
+
+func deserializeAccount(r *bytes.Reader) (*Account, error) {
+	acc := &Account{
+		TraderKey: &keychain.KeyDescriptor{},
+	}
+
+	if err := binary.Read(r, binary.BigEndian, &acc.TraderKey.Family); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &acc.TraderKey.Index); err != nil {
+		return nil, err
+	}
+
+	var err error
+	acc.TraderKey.PubKey, err = readPubKey(r)
+	if err != nil {
+		return nil, err
+	}
+	acc.AuctioneerKey, err = readPubKey(r)
+	if err != nil {
+		return nil, err
+	}
+	acc.BatchKey, err = readPubKey(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.Read(acc.Secret[:]); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Read(r, binary.BigEndian, &acc.Expiry); err != nil {
+		return nil, err
+	}
+
+	var version uint8
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return nil, err
+	}
+	acc.Version = Version(version)
+
+	var value int64
+	if err := binary.Read(r, binary.BigEndian, &value); err != nil {
+		return nil, err
+	}
+	acc.Value = btcutil.Amount(value)
+
+	if _, err := r.Read(acc.OutPoint.Hash[:]); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &acc.OutPoint.Index); err != nil {
+		return nil, err
+	}
+
+	acc.LatestTx = &wire.MsgTx{}
+	if err := acc.LatestTx.Deserialize(r); err != nil {
+		return nil, err
+	}
+
+	return acc, nil
+}
+
+func readPubKey(r *bytes.Reader) (*btcec.PublicKey, error) {
+	var raw [33]byte
+	if _, err := r.Read(raw[:]); err != nil {
+		return nil, err
+	}
+
+	return btcec.ParsePubKey(raw[:], btcec.S256())
+}
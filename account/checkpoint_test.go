@@ -0,0 +1,87 @@
+package account
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// TestBoltCheckpointSaveLoad checks that progress saved to a BoltCheckpoint
+// can be loaded back unchanged, including any accounts already recovered.
+func TestBoltCheckpointSaveLoad(t *testing.T) {
+	t.Parallel()
+
+	dbPath := filepath.Join(t.TempDir(), "recovery.db")
+	checkpoint, err := NewBoltCheckpoint(dbPath)
+	if err != nil {
+		t.Fatalf("unable to create checkpoint: %v", err)
+	}
+	defer checkpoint.Close()
+
+	if existing, err := checkpoint.Load(); err != nil {
+		t.Fatalf("unable to load empty checkpoint: %v", err)
+	} else if existing != nil {
+		t.Fatalf("expected no progress to be saved yet")
+	}
+
+	traderKey, _ := DecodeAndParseKey(
+		"0214cd678a565041d00e6cf8d62ef8add33b4af4786fb2beb87b366a2e1" +
+			"51fcee7",
+	)
+	acc := &Account{
+		TraderKey: &keychain.KeyDescriptor{
+			KeyLocator: keychain.KeyLocator{Index: 3},
+			PubKey:     traderKey,
+		},
+		AuctioneerKey: getAuctioneerKey(),
+		BatchKey:      getInitialBatchKey(),
+		Secret:        getSecret(),
+		Expiry:        177,
+		Version:       VersionLegacy,
+		OutPoint:      wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0},
+		LatestTx:      wire.NewMsgTx(2),
+	}
+
+	progress := &RecoveryProgress{
+		LastTraderKeyIndex: 3,
+		LastBatchKeyIndex:  7,
+		LastScannedBlock:   150,
+		RecoveredAccounts:  []*Account{acc},
+	}
+
+	if err := checkpoint.Save(progress); err != nil {
+		t.Fatalf("unable to save progress: %v", err)
+	}
+
+	loaded, err := checkpoint.Load()
+	if err != nil {
+		t.Fatalf("unable to load progress: %v", err)
+	}
+
+	if loaded.LastTraderKeyIndex != progress.LastTraderKeyIndex {
+		t.Fatalf("trader key index mismatch: got %d wanted %d",
+			loaded.LastTraderKeyIndex, progress.LastTraderKeyIndex)
+	}
+	if loaded.LastBatchKeyIndex != progress.LastBatchKeyIndex {
+		t.Fatalf("batch key index mismatch: got %d wanted %d",
+			loaded.LastBatchKeyIndex, progress.LastBatchKeyIndex)
+	}
+	if len(loaded.RecoveredAccounts) != 1 {
+		t.Fatalf("expected 1 recovered account, got %d",
+			len(loaded.RecoveredAccounts))
+	}
+
+	gotAcc := loaded.RecoveredAccounts[0]
+	if gotAcc.TraderKey.Index != acc.TraderKey.Index {
+		t.Fatalf("recovered account trader key index mismatch")
+	}
+	if !gotAcc.TraderKey.PubKey.IsEqual(acc.TraderKey.PubKey) {
+		t.Fatalf("recovered account trader key mismatch")
+	}
+	if gotAcc.Expiry != acc.Expiry {
+		t.Fatalf("recovered account expiry mismatch")
+	}
+}
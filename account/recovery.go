@@ -1,10 +1,14 @@
 package account
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
 	"github.com/lightninglabs/lndclient"
 	"github.com/lightninglabs/pool/poolscript"
 	"github.com/lightningnetwork/lnd/keychain"
@@ -17,6 +21,27 @@ var (
 	// necessarily make as many requests on recovery, if no accounts are
 	// found for a certain number of tries.
 	DefaultAccountKeyWindow uint32 = 500
+
+	// defaultMaxNumBatchKeys is the maximum number of times we increment
+	// a candidate account's batch key looking for a match before giving
+	// up on it. The auctioneer's batch key is incremented once per
+	// batch, so this bounds how many batches we're willing to scan
+	// through per candidate account.
+	defaultMaxNumBatchKeys uint32 = 500
+)
+
+// Version denotes the script version of an on-chain account output, which
+// determines whether it is spent through the legacy P2WSH path or the
+// MuSig2 Taproot key-spend path.
+type Version uint8
+
+const (
+	// VersionLegacy is the original P2WSH witness script account output.
+	VersionLegacy Version = iota
+
+	// VersionTaproot is the P2TR MuSig2 key-spend account output, with
+	// an expiry timeout available as a script-path spend.
+	VersionTaproot
 )
 
 // GetAuctioneerData returns the auctioner data for a given environment.
@@ -83,6 +108,11 @@ type RecoveryConfig struct {
 
 	// Auctioneer public key.
 	AuctioneerPubKey *btcec.PublicKey
+
+	// Checkpoint, if set, persists recovery progress so that a run
+	// interrupted partway through can be resumed without repeating
+	// already completed work. A nil Checkpoint disables persistence.
+	Checkpoint RecoveryCheckpoint
 }
 
 // RecoverAccounts tries to recover valid accounts using the given configuration.
@@ -111,9 +141,78 @@ func recoverInitalState(ctx context.Context, cfg RecoveryConfig) (
 		cfg.AccountTarget,
 	)
 
-	var accounts []*Account
+	var progress *RecoveryProgress
+	if cfg.Checkpoint != nil {
+		var err error
+		progress, err = cfg.Checkpoint.Load()
+		if err != nil {
+			return nil, fmt.Errorf("unable to load recovery "+
+				"checkpoint: %v", err)
+		}
+	}
+
+	accounts := make([]*Account, 0, cfg.AccountTarget)
+	startIndex := uint32(0)
+	startBatchKeyIndex := uint32(0)
+	if progress != nil {
+		accounts = append(accounts, progress.RecoveredAccounts...)
+		startIndex = progress.LastTraderKeyIndex
+		startBatchKeyIndex = progress.LastBatchKeyIndex
+
+		log.Debugf("Resuming recovery from checkpoint: %d accounts "+
+			"already found, resuming from trader key index %d, "+
+			"batch key index %d", len(accounts), startIndex,
+			startBatchKeyIndex)
+	}
 
-	// TODO (positiveblue): recover initial state
+	if cfg.AccountTarget > 0 && uint32(len(accounts)) >= cfg.AccountTarget {
+		return accounts, nil
+	}
+
+	acctKeys, err := GenerateRecoveryKeys(
+		ctx, DefaultAccountKeyWindow, cfg.Wallet,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate candidate "+
+			"account keys: %v", err)
+	}
+
+	// acctKeys is already ordered by index, so possibleAccounts preserves
+	// that order too. findAccounts relies on this to resume from the
+	// exact (trader key, batch key) pair recorded in the checkpoint.
+	possibleAccounts := make([]*Account, 0, len(acctKeys))
+	for _, acctKey := range acctKeys {
+		// Already tried in a previous run, no need to repeat the
+		// (expensive) search for it.
+		if acctKey.Index < startIndex {
+			continue
+		}
+
+		secret, err := cfg.Signer.DeriveSharedKey(
+			ctx, cfg.AuctioneerPubKey, &acctKey.KeyLocator,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to derive shared "+
+				"secret for key %x: %v",
+				acctKey.PubKey.SerializeCompressed(), err)
+		}
+
+		possibleAccounts = append(possibleAccounts, &Account{
+			TraderKey:     acctKey,
+			AuctioneerKey: cfg.AuctioneerPubKey,
+			Secret:        secret,
+		})
+	}
+
+	searchCfg := cfg
+	if searchCfg.AccountTarget > 0 {
+		searchCfg.AccountTarget -= uint32(len(accounts))
+	}
+
+	accounts = append(accounts, findAccounts(
+		searchCfg, accounts, possibleAccounts, startIndex,
+		startBatchKeyIndex,
+	)...)
 
 	log.Debugf(
 		"Found initial tx for %d/%d accounts", len(accounts),
@@ -123,6 +222,191 @@ func recoverInitalState(ctx context.Context, cfg RecoveryConfig) (
 	return accounts, nil
 }
 
+// findAccounts goes through every possible account, in trader key index
+// order, brute forcing the batch key and expiry height used for its funding
+// output, and returns the subset whose funding output was located among
+// cfg.Transactions. The search stops as soon as cfg.AccountTarget accounts
+// have been recovered. The candidate whose TraderKey.Index equals startIndex
+// resumes its batch key search at startBatchKeyIndex instead of starting
+// from scratch; every other candidate is searched in full. If cfg has a
+// checkpoint configured, progress is persisted as the search goes, alongside
+// priorRecovered, so the search can be resumed later without losing accounts
+// found in an earlier run.
+func findAccounts(cfg RecoveryConfig, priorRecovered []*Account,
+	possibleAccounts []*Account, startIndex,
+	startBatchKeyIndex uint32) []*Account {
+
+	var recovered []*Account
+
+	for _, acc := range possibleAccounts {
+		if cfg.AccountTarget > 0 &&
+			uint32(len(recovered)) >= cfg.AccountTarget {
+
+			break
+		}
+
+		batchKeyIndex := uint32(0)
+		if acc.TraderKey.Index == startIndex {
+			batchKeyIndex = startBatchKeyIndex
+		}
+
+		found, lastBatchKeyIndex := findInitialAccountOutput(
+			cfg, acc, batchKeyIndex,
+			func(triedBatchKeyIndex uint32) {
+				saveRecoveryProgress(
+					cfg, acc.TraderKey.Index,
+					triedBatchKeyIndex+1,
+					append(priorRecovered, recovered...),
+				)
+			},
+		)
+		if found != nil {
+			recovered = append(recovered, found)
+
+			// The search of this trader key is complete, so a
+			// resumed run doesn't find the same account again.
+			lastBatchKeyIndex = defaultMaxNumBatchKeys
+		}
+
+		saveRecoveryProgress(
+			cfg, acc.TraderKey.Index, lastBatchKeyIndex,
+			append(priorRecovered, recovered...),
+		)
+	}
+
+	return recovered
+}
+
+// saveRecoveryProgress persists the current recovery progress if cfg has a
+// checkpoint configured. Errors are logged but don't abort the search, since
+// a failed checkpoint write only risks redoing work on a future resume.
+func saveRecoveryProgress(cfg RecoveryConfig, traderKeyIndex,
+	batchKeyIndex uint32, recovered []*Account) {
+
+	if cfg.Checkpoint == nil {
+		return
+	}
+
+	err := cfg.Checkpoint.Save(&RecoveryProgress{
+		LastTraderKeyIndex: traderKeyIndex,
+		LastBatchKeyIndex:  batchKeyIndex,
+		RecoveredAccounts:  recovered,
+	})
+	if err != nil {
+		log.Errorf("unable to save recovery checkpoint: %v", err)
+	}
+}
+
+// findInitialAccountOutput tries to locate the funding output of acc by
+// trying every batch key index i from startBatchKeyIndex up to
+// defaultMaxNumBatchKeys, where index i stands for the batch key i+1
+// increments past cfg.InitialBatchKey, and every expiry height
+// in the [cfg.FirstBlock, cfg.LastBlock] range. Both the legacy P2WSH
+// account script and the newer P2TR script are tried for every
+// (batchKey, expiry) candidate, since a trader can't know up front which
+// version the auctioneer used. onBatchKeyTried, if non-nil, is invoked with
+// the index of every batch key that is exhausted so the caller can checkpoint
+// progress mid search. If a match is found, acc is populated with the matched
+// version, batch key, expiry, value and funding outpoint and returned, along
+// with the matching batch key index. Otherwise nil and defaultMaxNumBatchKeys
+// are returned.
+func findInitialAccountOutput(cfg RecoveryConfig, acc *Account,
+	startBatchKeyIndex uint32, onBatchKeyTried func(batchKeyIndex uint32)) (
+	*Account, uint32) {
+
+	batchKey := cfg.InitialBatchKey
+	for i := uint32(0); i < startBatchKeyIndex; i++ {
+		batchKey = poolscript.IncrementKey(batchKey)
+	}
+
+	for i := startBatchKeyIndex; i < defaultMaxNumBatchKeys; i++ {
+		batchKey = poolscript.IncrementKey(batchKey)
+
+		for expiry := cfg.FirstBlock; expiry <= cfg.LastBlock; expiry++ {
+			legacyScript, err := poolscript.AccountScript(
+				expiry, acc.TraderKey.PubKey,
+				acc.AuctioneerKey, batchKey, acc.Secret,
+			)
+			if err == nil {
+				tx, txOut, op, ok := findScriptOutput(
+					cfg.Transactions, legacyScript,
+				)
+				if ok {
+					return populateAccount(
+						acc, VersionLegacy, batchKey,
+						expiry, txOut, op, tx,
+					), i
+				}
+			}
+
+			taprootScript, err := poolscript.TaprootAccountScript(
+				expiry, acc.TraderKey.PubKey,
+				acc.AuctioneerKey, batchKey,
+			)
+			if err != nil {
+				continue
+			}
+
+			tx, txOut, op, ok := findScriptOutput(
+				cfg.Transactions, taprootScript,
+			)
+			if !ok {
+				continue
+			}
+
+			return populateAccount(
+				acc, VersionTaproot, batchKey, expiry, txOut,
+				op, tx,
+			), i
+		}
+
+		if onBatchKeyTried != nil {
+			onBatchKeyTried(i)
+		}
+	}
+
+	return nil, defaultMaxNumBatchKeys
+}
+
+// populateAccount fills in the fields of acc that are only known once its
+// funding output has been located on chain.
+func populateAccount(acc *Account, version Version, batchKey *btcec.PublicKey,
+	expiry uint32, txOut *wire.TxOut, op wire.OutPoint,
+	tx *wire.MsgTx) *Account {
+
+	acc.Version = version
+	acc.BatchKey = batchKey
+	acc.Expiry = expiry
+	acc.Value = btcutil.Amount(txOut.Value)
+	acc.OutPoint = op
+	acc.LatestTx = tx
+
+	return acc
+}
+
+// findScriptOutput looks for an output whose pkScript matches script among
+// the given transactions.
+func findScriptOutput(txs []lndclient.Transaction, script []byte) (
+	*wire.MsgTx, *wire.TxOut, wire.OutPoint, bool) {
+
+	for _, tx := range txs {
+		for i, out := range tx.Tx.TxOut {
+			if !bytes.Equal(out.PkScript, script) {
+				continue
+			}
+
+			op := wire.OutPoint{
+				Hash:  tx.Tx.TxHash(),
+				Index: uint32(i),
+			}
+
+			return tx.Tx, out, op, true
+		}
+	}
+
+	return nil, nil, wire.OutPoint{}, false
+}
+
 // updateAccountStates tries to update the states for every provided
 // account up to their latest state by following the on chain
 // modification footprints.
@@ -131,11 +415,128 @@ func updateAccountStates(cfg RecoveryConfig, accounts []*Account) (
 
 	recoveredAccounts := make([]*Account, 0, len(accounts))
 
-	// TODO (positiveblue): update account states
+	for _, acc := range accounts {
+		for {
+			tx, txOut, op, ok := findSpendingOutput(
+				cfg.Transactions, acc.OutPoint,
+			)
+			if !ok {
+				break
+			}
+
+			nextBatchKey := poolscript.IncrementKey(acc.BatchKey)
+			version, expiry, ok := matchAccountOutput(
+				cfg, acc, nextBatchKey, txOut,
+			)
+			if !ok {
+				// The outpoint was spent, but not into a
+				// recognizable continuation of the account
+				// (e.g. a genuine closure paying out to an
+				// unrelated address). Stop following it here.
+				break
+			}
+
+			acc.Version = version
+			acc.BatchKey = nextBatchKey
+			acc.Expiry = expiry
+			acc.Value = btcutil.Amount(txOut.Value)
+			acc.OutPoint = op
+			acc.LatestTx = tx
+
+			log.Debugf("Account %x was modified on-chain, new "+
+				"value=%v, batch_key=%x, expiry=%v",
+				acc.TraderKey.PubKey.SerializeCompressed(),
+				acc.Value, acc.BatchKey.SerializeCompressed(),
+				acc.Expiry)
+		}
+
+		recoveredAccounts = append(recoveredAccounts, acc)
+
+		if cfg.Checkpoint != nil {
+			// Accounts are updated in place, so accounts holds every
+			// recovered account in its latest known state. The key
+			// search indices are carried over unchanged.
+			progress := &RecoveryProgress{
+				LastScannedBlock:  cfg.LastBlock,
+				RecoveredAccounts: accounts,
+			}
+			prev, err := cfg.Checkpoint.Load()
+			if err == nil && prev != nil {
+				progress.LastTraderKeyIndex = prev.LastTraderKeyIndex
+				progress.LastBatchKeyIndex = prev.LastBatchKeyIndex
+			}
+
+			err = cfg.Checkpoint.Save(progress)
+			if err != nil {
+				log.Errorf("unable to save recovery "+
+					"checkpoint: %v", err)
+			}
+		}
+	}
 
 	return recoveredAccounts, nil
 }
 
+// matchAccountOutput checks whether txOut is a valid continuation of acc at
+// batchKey, by searching every expiry height in [cfg.FirstBlock,
+// cfg.LastBlock] for a legacy or Taproot account script whose pkScript
+// matches txOut.PkScript, the same way findInitialAccountOutput identifies
+// an account's funding output. If a match is found, the matched version and
+// expiry are returned.
+func matchAccountOutput(cfg RecoveryConfig, acc *Account,
+	batchKey *btcec.PublicKey, txOut *wire.TxOut) (Version, uint32, bool) {
+
+	for expiry := cfg.FirstBlock; expiry <= cfg.LastBlock; expiry++ {
+		legacyScript, err := poolscript.AccountScript(
+			expiry, acc.TraderKey.PubKey, acc.AuctioneerKey,
+			batchKey, acc.Secret,
+		)
+		if err == nil && bytes.Equal(legacyScript, txOut.PkScript) {
+			return VersionLegacy, expiry, true
+		}
+
+		taprootScript, err := poolscript.TaprootAccountScript(
+			expiry, acc.TraderKey.PubKey, acc.AuctioneerKey,
+			batchKey,
+		)
+		if err == nil && bytes.Equal(taprootScript, txOut.PkScript) {
+			return VersionTaproot, expiry, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// findSpendingOutput looks through txs for a transaction that spends op and,
+// if found, returns its first output as the successor account output.
+func findSpendingOutput(txs []lndclient.Transaction, op wire.OutPoint) (
+	*wire.MsgTx, *wire.TxOut, wire.OutPoint, bool) {
+
+	for _, tx := range txs {
+		for _, in := range tx.Tx.TxIn {
+			if in.PreviousOutPoint != op {
+				continue
+			}
+
+			// The account was spent but not re-created, so it
+			// must have been closed.
+			if len(tx.Tx.TxOut) == 0 {
+				return nil, nil, wire.OutPoint{}, false
+			}
+
+			newOut := tx.Tx.TxOut[0]
+			newOp := wire.OutPoint{
+				Hash:  tx.Tx.TxHash(),
+				Index: 0,
+			}
+
+			return tx.Tx, newOut, newOp, true
+		}
+	}
+
+	return nil, nil, wire.OutPoint{}, false
+}
+
 // GenerateRecoveryKeys generates a list of key descriptors for all possible
 // keys that could be used for trader accounts, up to a hard coHashded limit.
 func GenerateRecoveryKeys(ctx context.Context, accountTarget uint32,
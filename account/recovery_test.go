@@ -2,9 +2,12 @@ package account
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/lightninglabs/lndclient"
 	"github.com/lightninglabs/pool/internal/test"
@@ -86,8 +89,7 @@ func TestFindInitialAccountState(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 			possibleAccounts := make(
-				map[*Account]struct{},
-				len(tc.traderKeys),
+				[]*Account, 0, len(tc.traderKeys),
 			)
 
 			for idx, tk := range tc.traderKeys {
@@ -104,7 +106,7 @@ func TestFindInitialAccountState(t *testing.T) {
 					AuctioneerKey: tc.config.AuctioneerPubKey,
 					Secret:        getSecret(),
 				}
-				possibleAccounts[acc] = struct{}{}
+				possibleAccounts = append(possibleAccounts, acc)
 
 				script, _ := poolscript.AccountScript(
 					177,
@@ -130,7 +132,9 @@ func TestFindInitialAccountState(t *testing.T) {
 				)
 			}
 
-			accounts := findAccounts(tc.config, possibleAccounts)
+			accounts := findAccounts(
+				tc.config, nil, possibleAccounts, 0, 0,
+			)
 
 			if len(accounts) != tc.expectedAccounts {
 				t.Fatalf("number of accounts don't match, "+
@@ -141,6 +145,83 @@ func TestFindInitialAccountState(t *testing.T) {
 	}
 }
 
+// TestFindInitialAccountStateTaproot checks that we are able to find the
+// initial state for lost accounts that were funded with the newer P2TR
+// (MuSig2 Taproot) account script, analogous to TestFindInitialAccountState.
+func TestFindInitialAccountStateTaproot(t *testing.T) {
+	for _, tc := range findAccountTestCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			possibleAccounts := make(
+				[]*Account, 0, len(tc.traderKeys),
+			)
+
+			for idx, tk := range tc.traderKeys {
+				pubKey, _ := DecodeAndParseKey(tk)
+				kd := &keychain.KeyDescriptor{
+					KeyLocator: keychain.KeyLocator{
+						Index: uint32(idx),
+					},
+					PubKey: pubKey,
+				}
+
+				acc := &Account{
+					TraderKey:     kd,
+					AuctioneerKey: tc.config.AuctioneerPubKey,
+					Secret:        getSecret(),
+				}
+				possibleAccounts = append(possibleAccounts, acc)
+
+				script, err := poolscript.TaprootAccountScript(
+					177,
+					acc.TraderKey.PubKey,
+					tc.config.AuctioneerPubKey,
+					poolscript.IncrementKey(
+						tc.config.InitialBatchKey,
+					),
+				)
+				if err != nil {
+					t.Fatalf("unable to build taproot "+
+						"account script: %v", err)
+				}
+
+				tc.config.Transactions = append(
+					tc.config.Transactions,
+					lndclient.Transaction{
+						Tx: &wire.MsgTx{
+							TxOut: []*wire.TxOut{
+								{
+									PkScript: script,
+								},
+							},
+						},
+					},
+				)
+			}
+
+			accounts := findAccounts(
+				tc.config, nil, possibleAccounts, 0, 0,
+			)
+
+			if len(accounts) != tc.expectedAccounts {
+				t.Fatalf("number of accounts don't match, "+
+					"got %d wanted %d",
+					len(accounts), tc.expectedAccounts)
+			}
+
+			for _, acc := range accounts {
+				if acc.Version != VersionTaproot {
+					t.Fatalf("expected account to be "+
+						"recovered as taproot, got "+
+						"version %d", acc.Version)
+				}
+			}
+		})
+	}
+}
+
 // TestGenerateRecoveryKeys tests that a certain number of keys can be created
 // for account recovery.
 func TestGenerateRecoveryKeys(t *testing.T) {
@@ -159,3 +240,244 @@ func TestGenerateRecoveryKeys(t *testing.T) {
 			len(keys), DefaultAccountKeyWindow)
 	}
 }
+
+// TestUpdateAccountStates checks that updateAccountStates only follows a
+// spend of an account's outpoint when the new output matches an expected
+// account script, and that it picks up a changed expiry height.
+func TestUpdateAccountStates(t *testing.T) {
+	t.Parallel()
+
+	traderKeyPub, _ := DecodeAndParseKey(
+		"0214cd678a565041d00e6cf8d62ef8add33b4af4786fb2beb87b366a2e1" +
+			"51fcee7",
+	)
+	auctioneerKey := getAuctioneerKey()
+	secret := getSecret()
+	initialBatchKey := getInitialBatchKey()
+	nextBatchKey := poolscript.IncrementKey(initialBatchKey)
+
+	const (
+		initialExpiry = uint32(150)
+		nextExpiry    = uint32(160)
+	)
+
+	fundingOp := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+	acc := &Account{
+		TraderKey: &keychain.KeyDescriptor{
+			KeyLocator: keychain.KeyLocator{Index: 0},
+			PubKey:     traderKeyPub,
+		},
+		AuctioneerKey: auctioneerKey,
+		Secret:        secret,
+		BatchKey:      initialBatchKey,
+		Expiry:        initialExpiry,
+		Version:       VersionLegacy,
+		OutPoint:      fundingOp,
+	}
+
+	continuationScript, err := poolscript.AccountScript(
+		nextExpiry, traderKeyPub, auctioneerKey, nextBatchKey, secret,
+	)
+	if err != nil {
+		t.Fatalf("unable to build continuation script: %v", err)
+	}
+
+	spendTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{PreviousOutPoint: fundingOp}},
+		TxOut: []*wire.TxOut{
+			{PkScript: continuationScript, Value: 90_000},
+		},
+	}
+
+	cfg := RecoveryConfig{
+		AuctioneerPubKey: auctioneerKey,
+		FirstBlock:       100,
+		LastBlock:        200,
+		Transactions:     []lndclient.Transaction{{Tx: spendTx}},
+	}
+
+	accounts, err := updateAccountStates(cfg, []*Account{acc})
+	if err != nil {
+		t.Fatalf("unable to update account states: %v", err)
+	}
+	if len(accounts) != 1 {
+		t.Fatalf("expected 1 account, got %d", len(accounts))
+	}
+
+	got := accounts[0]
+	if got.Expiry != nextExpiry {
+		t.Fatalf("expected updated expiry %d, got %d", nextExpiry,
+			got.Expiry)
+	}
+	if !got.BatchKey.IsEqual(nextBatchKey) {
+		t.Fatalf("expected batch key to be incremented")
+	}
+	if got.OutPoint.Hash != spendTx.TxHash() {
+		t.Fatalf("expected outpoint to follow the spend")
+	}
+}
+
+// TestUpdateAccountStatesStopsOnUnrelatedSpend checks that a spend of an
+// account's outpoint into an output that isn't a recognizable account
+// script is treated as a closure rather than a continuation.
+func TestUpdateAccountStatesStopsOnUnrelatedSpend(t *testing.T) {
+	t.Parallel()
+
+	traderKeyPub, _ := DecodeAndParseKey(
+		"0214cd678a565041d00e6cf8d62ef8add33b4af4786fb2beb87b366a2e1" +
+			"51fcee7",
+	)
+	auctioneerKey := getAuctioneerKey()
+	secret := getSecret()
+	initialBatchKey := getInitialBatchKey()
+	const initialExpiry = uint32(150)
+
+	fundingOp := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+	acc := &Account{
+		TraderKey: &keychain.KeyDescriptor{
+			KeyLocator: keychain.KeyLocator{Index: 0},
+			PubKey:     traderKeyPub,
+		},
+		AuctioneerKey: auctioneerKey,
+		Secret:        secret,
+		BatchKey:      initialBatchKey,
+		Expiry:        initialExpiry,
+		Version:       VersionLegacy,
+		OutPoint:      fundingOp,
+	}
+
+	spendTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{PreviousOutPoint: fundingOp}},
+		TxOut: []*wire.TxOut{
+			{PkScript: []byte{txscript.OP_TRUE}, Value: 90_000},
+		},
+	}
+
+	cfg := RecoveryConfig{
+		AuctioneerPubKey: auctioneerKey,
+		FirstBlock:       100,
+		LastBlock:        200,
+		Transactions:     []lndclient.Transaction{{Tx: spendTx}},
+	}
+
+	accounts, err := updateAccountStates(cfg, []*Account{acc})
+	if err != nil {
+		t.Fatalf("unable to update account states: %v", err)
+	}
+	if len(accounts) != 1 {
+		t.Fatalf("expected 1 account, got %d", len(accounts))
+	}
+
+	got := accounts[0]
+	if got.Expiry != initialExpiry {
+		t.Fatalf("expected expiry to remain %d, got %d",
+			initialExpiry, got.Expiry)
+	}
+	if got.OutPoint != fundingOp {
+		t.Fatalf("expected outpoint to remain unchanged")
+	}
+}
+
+// TestFindAccountsResume checks that resuming a search from a checkpoint
+// neither skips over the batch key where the funding output actually sits
+// nor repeats a batch-key search that a previous run already completed, and
+// that the checkpoint retains accounts recovered in that previous run.
+func TestFindAccountsResume(t *testing.T) {
+	t.Parallel()
+
+	pubKey, _ := DecodeAndParseKey(
+		"0214cd678a565041d00e6cf8d62ef8add33b4af4786fb2beb87b366a2e1" +
+			"51fcee7",
+	)
+	cfg := RecoveryConfig{
+		AccountTarget:    1,
+		InitialBatchKey:  getInitialBatchKey(),
+		AuctioneerPubKey: getAuctioneerKey(),
+		FirstBlock:       100,
+		LastBlock:        200,
+	}
+
+	acc := &Account{
+		TraderKey: &keychain.KeyDescriptor{
+			KeyLocator: keychain.KeyLocator{Index: 5},
+			PubKey:     pubKey,
+		},
+		AuctioneerKey: cfg.AuctioneerPubKey,
+		Secret:        getSecret(),
+	}
+
+	// The funding output sits 10 batch-key increments past
+	// InitialBatchKey, i.e. at batch key index 9 in
+	// findInitialAccountOutput's i numbering.
+	const matchBatchKeyIndex = uint32(9)
+	batchKey := cfg.InitialBatchKey
+	for i := uint32(0); i <= matchBatchKeyIndex; i++ {
+		batchKey = poolscript.IncrementKey(batchKey)
+	}
+	script, err := poolscript.AccountScript(
+		177, acc.TraderKey.PubKey, cfg.AuctioneerPubKey, batchKey,
+		acc.Secret,
+	)
+	if err != nil {
+		t.Fatalf("unable to build account script: %v", err)
+	}
+	cfg.Transactions = []lndclient.Transaction{{
+		Tx: &wire.MsgTx{TxOut: []*wire.TxOut{{PkScript: script}}},
+	}}
+
+	// priorRecovered stands in for an account a previous run already
+	// found, which a resumed run must not lose track of.
+	priorRecovered := []*Account{{
+		TraderKey: &keychain.KeyDescriptor{
+			KeyLocator: keychain.KeyLocator{Index: 3},
+			PubKey:     getAuctioneerKey(),
+		},
+		AuctioneerKey: cfg.AuctioneerPubKey,
+		BatchKey:      cfg.InitialBatchKey,
+		Secret:        getSecret(),
+		Expiry:        150,
+		Version:       VersionLegacy,
+		OutPoint:      wire.OutPoint{Hash: chainhash.Hash{2}, Index: 0},
+		LatestTx:      wire.NewMsgTx(2),
+	}}
+
+	dbPath := filepath.Join(t.TempDir(), "recovery.db")
+	checkpoint, err := NewBoltCheckpoint(dbPath)
+	if err != nil {
+		t.Fatalf("unable to create checkpoint: %v", err)
+	}
+	defer checkpoint.Close()
+	cfg.Checkpoint = checkpoint
+
+	// Resuming from a batch key index past the match must not find the
+	// account: this proves the resumed search genuinely skips the batch
+	// keys a previous run already tried, instead of starting over.
+	skipped := findAccounts(
+		cfg, priorRecovered, []*Account{acc}, 5, matchBatchKeyIndex+1,
+	)
+	if len(skipped) != 0 {
+		t.Fatalf("expected no account found when resuming past the "+
+			"match, got %d", len(skipped))
+	}
+
+	// Resuming from the batch key index the match sits at must still
+	// find the account: this proves the resumed search doesn't skip
+	// over work that genuinely still needs to be done.
+	found := findAccounts(
+		cfg, priorRecovered, []*Account{acc}, 5, matchBatchKeyIndex,
+	)
+	if len(found) != 1 {
+		t.Fatalf("expected to find 1 account, got %d", len(found))
+	}
+
+	// The checkpoint must reflect both the account found in this run and
+	// the one carried over from the previous one.
+	progress, err := checkpoint.Load()
+	if err != nil {
+		t.Fatalf("unable to load checkpoint: %v", err)
+	}
+	if len(progress.RecoveredAccounts) != 2 {
+		t.Fatalf("expected checkpoint to carry 2 recovered accounts, "+
+			"got %d", len(progress.RecoveredAccounts))
+	}
+}
@@ -0,0 +1,262 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightninglabs/pool/poolscript"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+)
+
+// ChainSource is the minimal on-chain query interface SweepRecoveredAccount
+// needs in order to locate the current state of a recovered account's
+// output. It can be backed by lndclient's ChainNotifier/WalletKit, or by any
+// other chain backend for testing.
+type ChainSource interface {
+	// FetchUtxo returns the unspent output currently sitting at op.
+	FetchUtxo(ctx context.Context, op wire.OutPoint) (*wire.TxOut, error)
+}
+
+// SweepRecoveredAccount fetches the current on-chain state of a recovered
+// account, reconciling its batch key with the auctioneer's if the account
+// has moved further than the trader is aware of, and returns a fully signed
+// transaction sweeping its remaining value to sweepAddr. Sweeping is only
+// possible once the account has expired, so the timeout path of the
+// account script is always used, regardless of its version.
+func SweepRecoveredAccount(ctx context.Context, cfg RecoveryConfig,
+	chain ChainSource, acc *Account, sweepAddr btcutil.Address,
+	feeRate chainfee.SatPerKWeight) (*wire.MsgTx, error) {
+
+	utxo, err := chain.FetchUtxo(ctx, acc.OutPoint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch current utxo for "+
+			"account %x: %v",
+			acc.TraderKey.PubKey.SerializeCompressed(), err)
+	}
+
+	if err := reconcileBatchKey(acc, utxo); err != nil {
+		return nil, err
+	}
+
+	sweepTx, err := buildSweepTx(acc, utxo, sweepAddr, feeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	witness, err := signSweepTx(ctx, cfg, acc, utxo, sweepTx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign sweep tx: %v", err)
+	}
+	sweepTx.TxIn[0].Witness = witness
+
+	return sweepTx, nil
+}
+
+// reconcileBatchKey walks acc's batch key forward with IncrementKey until
+// its expected pkScript matches utxo's, up to defaultMaxNumBatchKeys tries.
+// This is needed because the auctioneer's committed batch key may have
+// advanced further than the last state the trader recovered.
+func reconcileBatchKey(acc *Account, utxo *wire.TxOut) error {
+	batchKey := acc.BatchKey
+
+	for i := uint32(0); i < defaultMaxNumBatchKeys; i++ {
+		legacyScript, err := poolscript.AccountScript(
+			acc.Expiry, acc.TraderKey.PubKey, acc.AuctioneerKey,
+			batchKey, acc.Secret,
+		)
+		if err == nil && bytes.Equal(legacyScript, utxo.PkScript) {
+			acc.Version = VersionLegacy
+			acc.BatchKey = batchKey
+			return nil
+		}
+
+		taprootScript, err := poolscript.TaprootAccountScript(
+			acc.Expiry, acc.TraderKey.PubKey, acc.AuctioneerKey,
+			batchKey,
+		)
+		if err == nil && bytes.Equal(taprootScript, utxo.PkScript) {
+			acc.Version = VersionTaproot
+			acc.BatchKey = batchKey
+			return nil
+		}
+
+		batchKey = poolscript.IncrementKey(batchKey)
+	}
+
+	return fmt.Errorf("unable to reconcile batch key for account %x "+
+		"within %d tries",
+		acc.TraderKey.PubKey.SerializeCompressed(),
+		defaultMaxNumBatchKeys)
+}
+
+// buildSweepTx constructs the unsigned sweep transaction spending utxo to
+// sweepAddr, subtracting the fee at feeRate from the swept value. Since the
+// expiry timeout path is enforced through an absolute height, the
+// transaction's locktime is set to acc.Expiry and its input sequence is left
+// non-final so the locktime is actually honored.
+func buildSweepTx(acc *Account, utxo *wire.TxOut, sweepAddr btcutil.Address,
+	feeRate chainfee.SatPerKWeight) (*wire.MsgTx, error) {
+
+	sweepScript, err := txscript.PayToAddrScript(sweepAddr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create sweep script: %v",
+			err)
+	}
+
+	sweepTx := wire.NewMsgTx(2)
+	sweepTx.LockTime = acc.Expiry
+	sweepTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: acc.OutPoint,
+		Sequence:         wire.MaxTxInSequenceNum - 1,
+	})
+	sweepTx.AddTxOut(&wire.TxOut{
+		PkScript: sweepScript,
+		Value:    utxo.Value,
+	})
+
+	// Size the witness the same way signSweepTx will, and use it to
+	// compute the fee against the transaction's actual weight rather
+	// than the bare pkScript size.
+	placeholderWitness, err := estimateSweepWitness(acc)
+	if err != nil {
+		return nil, err
+	}
+	sweepTx.TxIn[0].Witness = placeholderWitness
+
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(sweepTx))
+	fee := feeRate.FeeForWeight(weight)
+
+	sweepTx.TxIn[0].Witness = nil
+	sweepTx.TxOut[0].Value = utxo.Value - int64(fee)
+
+	return sweepTx, nil
+}
+
+// estimateSweepWitness returns a worst-case witness stack for acc's expiry
+// spend path, sized the same as the witness signSweepTx will eventually
+// produce, so buildSweepTx can weigh the transaction before it's signed.
+func estimateSweepWitness(acc *Account) (wire.TxWitness, error) {
+	switch acc.Version {
+	case VersionLegacy:
+		witnessScript, err := poolscript.AccountScript(
+			acc.Expiry, acc.TraderKey.PubKey, acc.AuctioneerKey,
+			acc.BatchKey, acc.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// A DER signature plus sighash byte is at most 73 bytes.
+		return poolscript.SpendExpiry(
+			witnessScript, make([]byte, 73),
+		), nil
+
+	case VersionTaproot:
+		leafScript, controlBlock, err :=
+			poolscript.TaprootExpiryWitnessScript(
+				acc.Expiry, acc.TraderKey.PubKey,
+				acc.AuctioneerKey, acc.BatchKey,
+			)
+		if err != nil {
+			return nil, err
+		}
+
+		// A BIP340 schnorr signature is 64 bytes.
+		return wire.TxWitness{
+			make([]byte, 64), leafScript, controlBlock,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown account version %v",
+			acc.Version)
+	}
+}
+
+// signSweepTx produces the witness needed to spend utxo into sweepTx via the
+// expiry timeout path, using the expiry witness stack of poolscript.SpendExpiry
+// for legacy accounts or the script-path spend of the expiry leaf for Taproot
+// accounts.
+func signSweepTx(ctx context.Context, cfg RecoveryConfig, acc *Account,
+	utxo *wire.TxOut, sweepTx *wire.MsgTx) (wire.TxWitness, error) {
+
+	switch acc.Version {
+	case VersionLegacy:
+		witnessScript, err := poolscript.AccountScript(
+			acc.Expiry, acc.TraderKey.PubKey, acc.AuctioneerKey,
+			acc.BatchKey, acc.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sigHashes := txscript.NewTxSigHashes(sweepTx)
+		signDesc := &input.SignDescriptor{
+			KeyDesc:       *acc.TraderKey,
+			WitnessScript: witnessScript,
+			Output:        utxo,
+			HashType:      txscript.SigHashAll,
+			SigHashes:     sigHashes,
+			InputIndex:    0,
+		}
+
+		sigs, err := cfg.Signer.SignOutputRaw(
+			ctx, sweepTx, []*input.SignDescriptor{signDesc},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		traderSig := append(sigs[0], byte(txscript.SigHashAll))
+
+		return poolscript.SpendExpiry(witnessScript, traderSig), nil
+
+	case VersionTaproot:
+		leafScript, controlBlock, err :=
+			poolscript.TaprootExpiryWitnessScript(
+				acc.Expiry, acc.TraderKey.PubKey,
+				acc.AuctioneerKey, acc.BatchKey,
+			)
+		if err != nil {
+			return nil, err
+		}
+
+		// Taproot sighashing (BIP341) needs the full set of spent
+		// outputs, unlike legacy sighashing, so a fetcher covering
+		// this input is required.
+		prevOutputFetcher := txscript.NewCannedPrevOutputFetcher(
+			utxo.PkScript, utxo.Value,
+		)
+		sigHashes := txscript.NewTxSigHashes(
+			sweepTx, prevOutputFetcher,
+		)
+
+		signDesc := &input.SignDescriptor{
+			KeyDesc:       *acc.TraderKey,
+			WitnessScript: leafScript,
+			Output:        utxo,
+			HashType:      txscript.SigHashDefault,
+			SigHashes:     sigHashes,
+			InputIndex:    0,
+			SignMethod:    input.TaprootScriptSpendSignMethod,
+		}
+
+		sig, err := cfg.Signer.SignOutputRaw(
+			ctx, sweepTx, []*input.SignDescriptor{signDesc},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return wire.TxWitness{sig[0], leafScript, controlBlock}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown account version %v",
+			acc.Version)
+	}
+}
@@ -0,0 +1,221 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/lightninglabs/pool/internal/test"
+	"github.com/lightninglabs/pool/poolscript"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+)
+
+// mockChainSource is a ChainSource backed by a fixed in-memory UTXO, used to
+// avoid pulling in a full chain backend for this test.
+type mockChainSource struct {
+	op  wire.OutPoint
+	out *wire.TxOut
+}
+
+func (m *mockChainSource) FetchUtxo(_ context.Context, op wire.OutPoint) (
+	*wire.TxOut, error) {
+
+	if op != m.op {
+		return nil, fmt.Errorf("no utxo found for %v", op)
+	}
+
+	return m.out, nil
+}
+
+// TestSweepRecoveredAccount checks that a recovered legacy account can be
+// swept into a fully signed transaction once it has reached its expiry.
+func TestSweepRecoveredAccount(t *testing.T) {
+	t.Parallel()
+
+	traderPrivKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create trader key: %v", err)
+	}
+	traderKey := &keychain.KeyDescriptor{
+		KeyLocator: keychain.KeyLocator{Index: 0},
+		PubKey:     traderPrivKey.PubKey(),
+	}
+
+	cfg := RecoveryConfig{
+		InitialBatchKey:  getInitialBatchKey(),
+		AuctioneerPubKey: getAuctioneerKey(),
+		Signer:           test.NewMockSigner(traderPrivKey),
+	}
+
+	batchKey := poolscript.IncrementKey(cfg.InitialBatchKey)
+	expiry := uint32(177)
+	secret := getSecret()
+
+	witnessScript, err := poolscript.AccountScript(
+		expiry, traderKey.PubKey, cfg.AuctioneerPubKey, batchKey,
+		secret,
+	)
+	if err != nil {
+		t.Fatalf("unable to build account script: %v", err)
+	}
+
+	op := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+	utxo := &wire.TxOut{PkScript: witnessScript, Value: 100_000}
+
+	acc := &Account{
+		TraderKey:     traderKey,
+		AuctioneerKey: cfg.AuctioneerPubKey,
+		Secret:        secret,
+		BatchKey:      batchKey,
+		Expiry:        expiry,
+		Version:       VersionLegacy,
+		OutPoint:      op,
+	}
+
+	chain := &mockChainSource{op: op, out: utxo}
+	sweepAddr, err := btcutil.NewAddressWitnessScriptHash(
+		witnessScript, &chaincfg.RegressionNetParams,
+	)
+	if err != nil {
+		t.Fatalf("unable to create sweep addr: %v", err)
+	}
+
+	sweepTx, err := SweepRecoveredAccount(
+		context.Background(), cfg, chain, acc, sweepAddr,
+		chainfee.FeePerKwFloor,
+	)
+	if err != nil {
+		t.Fatalf("unable to sweep account: %v", err)
+	}
+
+	if len(sweepTx.TxIn) != 1 {
+		t.Fatalf("expected 1 input, got %d", len(sweepTx.TxIn))
+	}
+	if sweepTx.TxIn[0].PreviousOutPoint != op {
+		t.Fatalf("sweep tx spends unexpected outpoint")
+	}
+	if len(sweepTx.TxIn[0].Witness) == 0 {
+		t.Fatalf("expected sweep tx input to carry a witness")
+	}
+	if sweepTx.LockTime != expiry {
+		t.Fatalf("expected tx locktime %d, got %d", expiry,
+			sweepTx.LockTime)
+	}
+	if sweepTx.TxIn[0].Sequence == wire.MaxTxInSequenceNum {
+		t.Fatalf("sequence must be non-final for locktime to apply")
+	}
+
+	sigHashes := txscript.NewTxSigHashes(sweepTx)
+	engine, err := txscript.NewEngine(
+		utxo.PkScript, sweepTx, 0, txscript.StandardVerifyFlags, nil,
+		sigHashes, utxo.Value,
+	)
+	if err != nil {
+		t.Fatalf("unable to create script engine: %v", err)
+	}
+	if err := engine.Execute(); err != nil {
+		t.Fatalf("sweep tx failed script validation: %v", err)
+	}
+}
+
+// TestSweepRecoveredAccountTaproot checks that a recovered Taproot account
+// can be swept into a fully signed transaction once it has reached its
+// expiry, analogous to TestSweepRecoveredAccount.
+func TestSweepRecoveredAccountTaproot(t *testing.T) {
+	t.Parallel()
+
+	traderPrivKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create trader key: %v", err)
+	}
+	traderKey := &keychain.KeyDescriptor{
+		KeyLocator: keychain.KeyLocator{Index: 0},
+		PubKey:     traderPrivKey.PubKey(),
+	}
+
+	cfg := RecoveryConfig{
+		InitialBatchKey:  getInitialBatchKey(),
+		AuctioneerPubKey: getAuctioneerKey(),
+		Signer:           test.NewMockSigner(traderPrivKey),
+	}
+
+	batchKey := poolscript.IncrementKey(cfg.InitialBatchKey)
+	expiry := uint32(177)
+	secret := getSecret()
+
+	outputScript, err := poolscript.TaprootAccountScript(
+		expiry, traderKey.PubKey, cfg.AuctioneerPubKey, batchKey,
+	)
+	if err != nil {
+		t.Fatalf("unable to build taproot account script: %v", err)
+	}
+
+	op := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+	utxo := &wire.TxOut{PkScript: outputScript, Value: 100_000}
+
+	acc := &Account{
+		TraderKey:     traderKey,
+		AuctioneerKey: cfg.AuctioneerPubKey,
+		Secret:        secret,
+		BatchKey:      batchKey,
+		Expiry:        expiry,
+		Version:       VersionTaproot,
+		OutPoint:      op,
+	}
+
+	chain := &mockChainSource{op: op, out: utxo}
+	sweepAddr, err := btcutil.NewAddressWitnessScriptHash(
+		outputScript, &chaincfg.RegressionNetParams,
+	)
+	if err != nil {
+		t.Fatalf("unable to create sweep addr: %v", err)
+	}
+
+	sweepTx, err := SweepRecoveredAccount(
+		context.Background(), cfg, chain, acc, sweepAddr,
+		chainfee.FeePerKwFloor,
+	)
+	if err != nil {
+		t.Fatalf("unable to sweep account: %v", err)
+	}
+
+	if len(sweepTx.TxIn) != 1 {
+		t.Fatalf("expected 1 input, got %d", len(sweepTx.TxIn))
+	}
+	if sweepTx.TxIn[0].PreviousOutPoint != op {
+		t.Fatalf("sweep tx spends unexpected outpoint")
+	}
+	if len(sweepTx.TxIn[0].Witness) != 3 {
+		t.Fatalf("expected a 3 element script-path witness, got %d",
+			len(sweepTx.TxIn[0].Witness))
+	}
+	if sweepTx.LockTime != expiry {
+		t.Fatalf("expected tx locktime %d, got %d", expiry,
+			sweepTx.LockTime)
+	}
+	if sweepTx.TxIn[0].Sequence == wire.MaxTxInSequenceNum {
+		t.Fatalf("sequence must be non-final for locktime to apply")
+	}
+
+	prevOutputFetcher := txscript.NewCannedPrevOutputFetcher(
+		utxo.PkScript, utxo.Value,
+	)
+	sigHashes := txscript.NewTxSigHashes(sweepTx, prevOutputFetcher)
+	engine, err := txscript.NewEngine(
+		utxo.PkScript, sweepTx, 0, txscript.StandardVerifyFlags, nil,
+		sigHashes, utxo.Value, prevOutputFetcher,
+	)
+	if err != nil {
+		t.Fatalf("unable to create script engine: %v", err)
+	}
+	if err := engine.Execute(); err != nil {
+		t.Fatalf("sweep tx failed script validation: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/btcec/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// MockSigner is a mock implementation of lndclient.SignerClient that signs
+// with a fixed, caller-provided private key, regardless of which key locator
+// a sign request targets. It's only meant to exercise the account package's
+// sweep logic in tests.
+type MockSigner struct {
+	PrivKey *btcec.PrivateKey
+}
+
+// NewMockSigner creates a new mock signer that always signs/derives shared
+// keys with privKey.
+func NewMockSigner(privKey *btcec.PrivateKey) *MockSigner {
+	return &MockSigner{PrivKey: privKey}
+}
+
+// SignOutputRaw signs every descriptor in signDescs with the mock's fixed
+// private key.
+func (s *MockSigner) SignOutputRaw(_ context.Context, tx *wire.MsgTx,
+	signDescs []*input.SignDescriptor) ([][]byte, error) {
+
+	sigs := make([][]byte, len(signDescs))
+	for i, signDesc := range signDescs {
+		if signDesc.SignMethod == input.TaprootScriptSpendSignMethod {
+			sig, err := s.signTaprootScriptSpend(tx, signDesc)
+			if err != nil {
+				return nil, err
+			}
+
+			sigs[i] = sig
+			continue
+		}
+
+		sig, err := txscript.RawTxInWitnessSignature(
+			tx, signDesc.SigHashes, signDesc.InputIndex,
+			signDesc.Output.Value, signDesc.WitnessScript,
+			signDesc.HashType, s.PrivKey,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// RawTxInWitnessSignature appends the sighash type, which
+		// callers of SignOutputRaw add themselves, so strip it.
+		sigs[i] = sig[:len(sig)-1]
+	}
+
+	return sigs, nil
+}
+
+// signTaprootScriptSpend produces a BIP340 schnorr signature over the
+// tapscript leaf referenced by signDesc, for a taproot script-path spend.
+func (s *MockSigner) signTaprootScriptSpend(tx *wire.MsgTx,
+	signDesc *input.SignDescriptor) ([]byte, error) {
+
+	prevOutputFetcher := txscript.NewCannedPrevOutputFetcher(
+		signDesc.Output.PkScript, signDesc.Output.Value,
+	)
+	leaf := txscript.NewBaseTapLeaf(signDesc.WitnessScript)
+
+	sigHash, err := txscript.CalcTapscriptSignaturehash(
+		signDesc.SigHashes, signDesc.HashType, tx,
+		signDesc.InputIndex, prevOutputFetcher, leaf,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := schnorr.Sign(s.PrivKey, sigHash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create schnorr "+
+			"signature: %v", err)
+	}
+
+	rawSig := sig.Serialize()
+	if signDesc.HashType != txscript.SigHashDefault {
+		rawSig = append(rawSig, byte(signDesc.HashType))
+	}
+
+	return rawSig, nil
+}
+
+// DeriveSharedKey returns a deterministic 32 byte "shared secret" derived
+// from the mock's fixed private key and ephemeralPubKey, ignoring the
+// requested key locator. It stands in for the ECDH normally performed by
+// lnd's wallet, which isn't available in tests.
+func (s *MockSigner) DeriveSharedKey(_ context.Context,
+	ephemeralPubKey *btcec.PublicKey, _ *keychain.KeyLocator) ([32]byte,
+	error) {
+
+	x, _ := btcec.S256().ScalarMult(
+		ephemeralPubKey.X, ephemeralPubKey.Y, s.PrivKey.D.Bytes(),
+	)
+
+	return sha256.Sum256(x.Bytes()), nil
+}
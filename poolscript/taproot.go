@@ -0,0 +1,127 @@
+package poolscript
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/btcec/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/lightningnetwork/lnd/input"
+)
+
+// expiryTapScript returns the tapscript leaf that allows the trader to
+// sweep the account unilaterally once it has expired, mirroring the
+// timeout path of the legacy P2WSH account script. OP_CHECKLOCKTIMEVERIFY
+// leaves its argument on the stack, so <expiry> is left behind as the leaf's
+// single required truthy value; it must not be OP_DROP'd afterwards.
+func expiryTapScript(traderKey *btcec.PublicKey, expiry uint32) ([]byte,
+	error) {
+
+	builder := txscript.NewScriptBuilder()
+	builder.AddData(schnorr.SerializePubKey(traderKey))
+	builder.AddOp(txscript.OP_CHECKSIGVERIFY)
+	builder.AddInt64(int64(expiry))
+	builder.AddOp(txscript.OP_CHECKLOCKTIMEVERIFY)
+
+	return builder.Script()
+}
+
+// taprootAccountComponents bundles the pieces derived when building an
+// account's P2TR output, shared by TaprootAccountScript and
+// TaprootExpiryWitnessScript.
+type taprootAccountComponents struct {
+	internalKey *btcec.PublicKey
+	leafScript  []byte
+	scriptTree  *txscript.IndexedTapScriptTree
+	taprootKey  *btcec.PublicKey
+}
+
+func buildTaprootAccountComponents(expiry uint32, traderKey, auctioneerKey,
+	batchKey *btcec.PublicKey) (*taprootAccountComponents, error) {
+
+	combinedKey, _, _, err := input.MuSig2CombineKeys(
+		input.MuSig2Version100RC2,
+		[]*btcec.PublicKey{traderKey, auctioneerKey},
+		true,
+		&input.MuSig2Tweaks{
+			GenericTweaks: [][]byte{
+				schnorr.SerializePubKey(batchKey),
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to combine trader and "+
+			"auctioneer keys: %v", err)
+	}
+
+	leafScript, err := expiryTapScript(traderKey, expiry)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build expiry script: %v",
+			err)
+	}
+
+	tapLeaf := txscript.NewBaseTapLeaf(leafScript)
+	tapScriptTree := txscript.AssembleTaprootScriptTree(tapLeaf)
+	tapScriptRoot := tapScriptTree.RootNode.TapHash()
+
+	taprootKey := txscript.ComputeTaprootOutputKey(
+		combinedKey.FinalKey, tapScriptRoot[:],
+	)
+
+	return &taprootAccountComponents{
+		internalKey: combinedKey.FinalKey,
+		leafScript:  leafScript,
+		scriptTree:  tapScriptTree,
+		taprootKey:  taprootKey,
+	}, nil
+}
+
+// TaprootAccountScript returns the pkScript for the P2TR version of an
+// account output. The output's internal key is the MuSig2 combination of
+// the trader and auctioneer keys tweaked by the current batch key, and the
+// taproot output key commits to a single script-path leaf that lets the
+// trader sweep the account unilaterally after it has expired. This is the
+// Taproot counterpart of AccountScript.
+func TaprootAccountScript(expiry uint32, traderKey, auctioneerKey,
+	batchKey *btcec.PublicKey) ([]byte, error) {
+
+	c, err := buildTaprootAccountComponents(
+		expiry, traderKey, auctioneerKey, batchKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := txscript.NewScriptBuilder()
+	builder.AddOp(txscript.OP_1)
+	builder.AddData(schnorr.SerializePubKey(c.taprootKey))
+
+	return builder.Script()
+}
+
+// TaprootExpiryWitnessScript returns the tapscript leaf script and the
+// serialized control block needed to spend an account's P2TR output through
+// its expiry timeout path, i.e. the last two items of the witness stack for
+// a script-path spend of the leaf produced by TaprootAccountScript.
+func TaprootExpiryWitnessScript(expiry uint32, traderKey, auctioneerKey,
+	batchKey *btcec.PublicKey) (leafScript, controlBlock []byte,
+	err error) {
+
+	c, err := buildTaprootAccountComponents(
+		expiry, traderKey, auctioneerKey, batchKey,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctrlBlock := c.scriptTree.LeafMerkleProofs[0].ToControlBlock(
+		c.internalKey,
+	)
+	ctrlBlockBytes, err := ctrlBlock.ToBytes()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to serialize control "+
+			"block: %v", err)
+	}
+
+	return c.leafScript, ctrlBlockBytes, nil
+}
@@ -0,0 +1,92 @@
+package poolscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/btcec/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestTaprootExpiryWitness checks that the witness produced for the expiry
+// timeout path of a P2TR account output is actually accepted by the script
+// engine, guarding against the leaf script leaving the wrong value (or
+// nothing) on the stack.
+func TestTaprootExpiryWitness(t *testing.T) {
+	t.Parallel()
+
+	traderPrivKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create trader key: %v", err)
+	}
+	auctioneerPrivKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create auctioneer key: %v", err)
+	}
+	batchPrivKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to create batch key: %v", err)
+	}
+
+	const expiry = uint32(200)
+	const value = int64(100_000)
+
+	pkScript, err := TaprootAccountScript(
+		expiry, traderPrivKey.PubKey(), auctioneerPrivKey.PubKey(),
+		batchPrivKey.PubKey(),
+	)
+	if err != nil {
+		t.Fatalf("unable to build account script: %v", err)
+	}
+
+	leafScript, controlBlock, err := TaprootExpiryWitnessScript(
+		expiry, traderPrivKey.PubKey(), auctioneerPrivKey.PubKey(),
+		batchPrivKey.PubKey(),
+	)
+	if err != nil {
+		t.Fatalf("unable to build expiry witness script: %v", err)
+	}
+
+	spendTx := wire.NewMsgTx(2)
+	spendTx.LockTime = expiry
+	spendTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{
+			Hash: chainhash.Hash{1}, Index: 0,
+		},
+		Sequence: wire.MaxTxInSequenceNum - 1,
+	})
+	spendTx.AddTxOut(&wire.TxOut{PkScript: pkScript, Value: value})
+
+	prevOutFetcher := txscript.NewCannedPrevOutputFetcher(pkScript, value)
+	sigHashes := txscript.NewTxSigHashes(spendTx, prevOutFetcher)
+
+	sigHash, err := txscript.CalcTapscriptSignaturehash(
+		sigHashes, txscript.SigHashDefault, spendTx, 0,
+		prevOutFetcher, txscript.NewBaseTapLeaf(leafScript),
+	)
+	if err != nil {
+		t.Fatalf("unable to calculate sighash: %v", err)
+	}
+
+	sig, err := schnorr.Sign(traderPrivKey, sigHash)
+	if err != nil {
+		t.Fatalf("unable to sign: %v", err)
+	}
+
+	spendTx.TxIn[0].Witness = wire.TxWitness{
+		sig.Serialize(), leafScript, controlBlock,
+	}
+
+	engine, err := txscript.NewEngine(
+		pkScript, spendTx, 0, txscript.StandardVerifyFlags, nil,
+		sigHashes, value, prevOutFetcher,
+	)
+	if err != nil {
+		t.Fatalf("unable to create script engine: %v", err)
+	}
+	if err := engine.Execute(); err != nil {
+		t.Fatalf("expiry witness failed script validation: %v", err)
+	}
+}
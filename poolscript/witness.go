@@ -0,0 +1,17 @@
+package poolscript
+
+import "github.com/btcsuite/btcd/wire"
+
+// SpendExpiry returns the witness stack needed to spend an account output
+// through its expiry timeout branch, given a signature from the trader and
+// the account's witness script.
+func SpendExpiry(witnessScript, traderSig []byte) wire.TxWitness {
+	return wire.TxWitness{
+		traderSig,
+
+		// An empty vector selects the timeout branch of the
+		// account's OP_IF/OP_ELSE witness script.
+		nil,
+		witnessScript,
+	}
+}